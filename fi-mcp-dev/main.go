@@ -2,26 +2,33 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
-	"github.com/samber/lo"
 
 	"github.com/epifi/fi-mcp-lite/middlewares"
 	"github.com/epifi/fi-mcp-lite/pkg"
+	"github.com/epifi/fi-mcp-lite/pkg/pow"
 )
 
-var authMiddleware *middlewares.AuthMiddleware
+var (
+	authMiddleware *middlewares.AuthMiddleware
+	powManager     *pow.Manager
+)
 
 func main() {
 	// We still create this server object, but we will NOT use it for the /mcp/stream endpoint.
-	authMiddleware = middlewares.NewAuthMiddleware()
+	authMiddleware = middlewares.NewAuthMiddleware(middlewares.NewSessionStore())
+	powManager = pow.NewManager(pkg.GetPowSecret(), pkg.GetPowDifficulty(), pkg.GetPowChallengeTTL(), pkg.GetPowReplayCacheSize())
 	s := server.NewMCPServer(
 		"Hackathon MCP", "0.1.0",
 		server.WithToolHandlerMiddleware(authMiddleware.AuthMiddleware),
@@ -35,61 +42,72 @@ func main() {
 	httpMux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 
 	// Use our own simple, working handler for the /mcp/stream endpoint instead of the library's.
-	httpMux.HandleFunc("/mcp/stream", manualMcpStreamHandler)
+	httpMux.Handle("/mcp/stream", middlewares.AllowedMethod(http.MethodPost, http.HandlerFunc(manualMcpStreamHandler)))
+	httpMux.Handle("/mcp/cancel", middlewares.AllowedMethod(http.MethodPost, http.HandlerFunc(mcpCancelHandler)))
 
 	// Keep the handlers for the web-based login flow.
-	httpMux.HandleFunc("/mockWebPage", webPageHandler)
-	httpMux.HandleFunc("/login", loginHandler)
-
-	port := pkg.GetPort()
-	log.Println("starting server on port:", port)
-	if servErr := http.ListenAndServe(fmt.Sprintf(":%s", port), httpMux); servErr != nil {
-		log.Fatalln("error starting server", servErr)
+	httpMux.Handle("/mockWebPage", middlewares.AllowedMethod(http.MethodGet, http.HandlerFunc(webPageHandler)))
+	httpMux.Handle("/login", middlewares.AllowedMethod(http.MethodPost, http.HandlerFunc(loginHandler)))
+	httpMux.Handle("/pow/challenge", middlewares.AllowedMethod(http.MethodGet, http.HandlerFunc(powChallengeHandler)))
+
+	// RequestID runs first so everything inside it - including a recovered
+	// panic and the access log line - can report the request ID. Logger
+	// sits outside Recovery so a panicking request still gets its access
+	// log line (with the 500 Recovery wrote) instead of only a
+	// PANIC RECOVERED line. MTLSAuth sits just outside the mux so handlers
+	// can resolve the caller's phone number from either a client cert or a
+	// session, transparently.
+	handler := middlewares.RequestID(middlewares.Logger(middlewares.Recovery(middlewares.MTLSAuth(httpMux))))
+
+	tlsConfig, err := buildTLSConfig()
+	if err != nil {
+		log.Fatalln("error building TLS config", err)
 	}
-}
 
-// This is our new handler that replaces the broken library functionality.
-func manualMcpStreamHandler(w http.ResponseWriter, r *http.Request) {
-	// 1. Get Session ID from header.
-	sessionId := r.Header.Get("X-Session-ID")
-	log.Printf("MANUAL HANDLER: Checking session for ID '%s'\n", sessionId)
-
-	// 2. Check session in our global store.
-	phoneNumber, ok := middlewares.GetSession(sessionId)
-	if !ok {
-		log.Printf("MANUAL HANDLER: Session NOT FOUND for ID '%s'\n", sessionId)
-		http.Error(w, "Invalid session ID", http.StatusBadRequest)
-		return
+	httpServer := &http.Server{
+		Addr:      fmt.Sprintf(":%s", pkg.GetPort()),
+		Handler:   handler,
+		TLSConfig: tlsConfig,
 	}
-	log.Printf("MANUAL HANDLER: Session FOUND for ID '%s'. Using phone: %s\n", sessionId, phoneNumber)
 
-	// 3. Decode the request body to get the tool name.
-	var requestBody struct {
-		ToolName string `json:"tool_name"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		http.Error(w, "Could not decode request body", http.StatusBadRequest)
+	log.Println("starting server on port:", pkg.GetPort())
+	if tlsConfig != nil {
+		servErr := httpServer.ListenAndServeTLS(pkg.GetTLSCertFile(), pkg.GetTLSKeyFile())
+		if servErr != nil {
+			log.Fatalln("error starting server", servErr)
+		}
 		return
 	}
-	toolName := requestBody.ToolName
-
-	// 4. Check if phone number is allowed.
-	if !lo.Contains(pkg.GetAllowedMobileNumbers(), phoneNumber) {
-		http.Error(w, "Phone number is not allowed", http.StatusForbidden)
-		return
+	if servErr := httpServer.ListenAndServe(); servErr != nil {
+		log.Fatalln("error starting server", servErr)
 	}
+}
 
-	// 5. Read and return the dummy data file.
-	filePath := "test_data_dir/" + phoneNumber + "/" + toolName + ".json"
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		log.Printf("MANUAL HANDLER: Error reading test data file: %v\n", err)
-		http.Error(w, "Could not read tool data", http.StatusInternalServerError)
-		return
+// buildTLSConfig returns nil when TLS_MODE is "off" (the default), a
+// server-only TLS config for "tls", or an mTLS config requiring and
+// verifying client certificates for "mtls".
+func buildTLSConfig() (*tls.Config, error) {
+	switch pkg.GetTLSMode() {
+	case "off", "":
+		return nil, nil
+	case "tls":
+		return &tls.Config{}, nil
+	case "mtls":
+		caPEM, err := os.ReadFile(pkg.GetTLSClientCAFile())
+		if err != nil {
+			return nil, fmt.Errorf("reading TLS_CLIENT_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in TLS_CLIENT_CA_FILE")
+		}
+		return &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  pool,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown TLS_MODE %q", pkg.GetTLSMode())
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(data)
 }
 
 // dummyHandler is kept for compatibility but is not used in the main flow.
@@ -97,13 +115,19 @@ func dummyHandler(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult
 	return mcp.NewToolResultText("dummy handler"), nil
 }
 
-// webPageHandler remains the same.
+// webPageHandler renders the mock login page, along with a CSRF token tied
+// to a cookie that loginHandler verifies on submit.
 func webPageHandler(w http.ResponseWriter, r *http.Request) {
 	sessionId := r.URL.Query().Get("sessionId")
 	if sessionId == "" {
 		http.Error(w, "sessionId is required", http.StatusBadRequest)
 		return
 	}
+	csrfToken, err := middlewares.NewCSRFToken(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	tmpl, err := template.ParseFiles("static/login.html")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -112,9 +136,11 @@ func webPageHandler(w http.ResponseWriter, r *http.Request) {
 	data := struct {
 		SessionId            string
 		AllowedMobileNumbers []string
+		CSRFToken            string
 	}{
 		SessionId:            sessionId,
 		AllowedMobileNumbers: pkg.GetAllowedMobileNumbers(),
+		CSRFToken:            csrfToken,
 	}
 	err = tmpl.Execute(w, data)
 	if err != nil {
@@ -122,10 +148,30 @@ func webPageHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// loginHandler remains the same.
+// powChallengeHandler hands out a freshly-signed PoW challenge that the
+// client must solve before /login will accept its submission.
+func powChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	challenge, err := powManager.NewChallenge()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(challenge); err != nil {
+		log.Printf("MCP SERVER: error encoding pow challenge: %v\n", err)
+	}
+}
+
+// loginHandler is guarded by middlewares.AllowedMethod(http.MethodPost, ...) in main, so
+// by the time we get here the method is already known to be POST.
 func loginHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !middlewares.ValidCSRFToken(r) {
+		http.Error(w, "invalid or missing CSRF token", http.StatusForbidden)
+		return
+	}
+	if err := verifyLoginPow(r); err != nil {
+		log.Printf("MCP SERVER: pow verification failed: %v\n", err)
+		http.Error(w, "pow verification failed", http.StatusForbidden)
 		return
 	}
 	sessionId := r.FormValue("sessionId")
@@ -146,3 +192,16 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// verifyLoginPow pulls the pow_* form fields submitted alongside the login
+// form and checks them against powManager.
+func verifyLoginPow(r *http.Request) error {
+	seed := r.FormValue("pow_seed")
+	signature := r.FormValue("pow_sig")
+	solution := r.FormValue("pow_solution")
+	expiresAt, err := strconv.ParseInt(r.FormValue("pow_expires"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid pow_expires: %w", err)
+	}
+	return powManager.Verify(seed, expiresAt, pkg.GetPowDifficulty(), signature, solution)
+}
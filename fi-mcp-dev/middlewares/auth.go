@@ -3,7 +3,6 @@ package middlewares
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -17,25 +16,31 @@ var (
 	loginRequiredJson = `{"status": "login_required","login_url": "%s","message": "Needs to login first by going to the login url.\nShow the login url as clickable link if client supports it. Otherwise display the URL for users to copy and paste into a browser. \nAsk users to come back and let you know once they are done with login in their browser"}`
 )
 
-// Use a global map to solve the architectural issue.
-var globalSessionStore = make(map[string]string)
+// activeSessionStore backs the package-level AddSession/GetSession helpers
+// used by the HTTP handlers in main.go. It's set by NewAuthMiddleware so
+// the HTTP and MCP tool-call paths always agree on the same store.
+var activeSessionStore SessionStore
 
-// This struct is kept for compatibility but its internal store is no longer used.
-type AuthMiddleware struct{}
+type AuthMiddleware struct {
+	store SessionStore
+}
 
-func NewAuthMiddleware() *AuthMiddleware {
-	return &AuthMiddleware{}
+// NewAuthMiddleware wires the middleware, and the package-level session
+// helpers, to store.
+func NewAuthMiddleware(store SessionStore) *AuthMiddleware {
+	activeSessionStore = store
+	return &AuthMiddleware{store: store}
 }
 
-// This function is now used by the /login handler in main.go.
+// AddSession is used by the /login handler in main.go.
 func AddSession(sessionId, phoneNumber string) {
-	globalSessionStore[sessionId] = phoneNumber
+	activeSessionStore.Put(sessionId, phoneNumber, pkg.GetSessionTTL())
 }
 
-// This new function is used by our manual handler in main.go.
+// GetSession is used by ResolvePhoneNumber, which the MCP stream handler in
+// stream.go calls.
 func GetSession(sessionId string) (string, bool) {
-	phone, ok := globalSessionStore[sessionId]
-	return phone, ok
+	return activeSessionStore.Get(sessionId)
 }
 
 // This middleware function is no longer used by the main API flow but is kept for compatibility.
@@ -43,16 +48,16 @@ func (m *AuthMiddleware) AuthMiddleware(next server.ToolHandlerFunc) server.Tool
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		sessionId := server.ClientSessionFromContext(ctx).SessionID()
 
-		log.Printf("MCP MIDDLEWARE: Checking session for ID '%s'\n", sessionId)
+		Logf("mcp_middleware_checking_session", "session_id", sessionId)
 
-		phoneNumber, ok := globalSessionStore[sessionId]
+		phoneNumber, ok := m.store.Get(sessionId)
 		if !ok {
-			log.Printf("MCP MIDDLEWARE: Session NOT FOUND for ID '%s'\n", sessionId)
+			Logf("mcp_middleware_session_not_found", "session_id", sessionId)
 			loginUrl := m.getLoginUrl(sessionId)
 			return mcp.NewToolResultText(fmt.Sprintf(loginRequiredJson, loginUrl)), nil
 		}
 
-		log.Printf("MCP MIDDLEWARE: Session FOUND for ID '%s'. Using phone: %s\n", sessionId, phoneNumber)
+		Logf("mcp_middleware_session_found", "session_id", sessionId, "phone_number", phoneNumber)
 
 		if !lo.Contains(pkg.GetAllowedMobileNumbers(), phoneNumber) {
 			return mcp.NewToolResultError("phone number is not allowed"), nil
@@ -62,7 +67,7 @@ func (m *AuthMiddleware) AuthMiddleware(next server.ToolHandlerFunc) server.Tool
 		toolName := req.Params.Name
 		data, readErr := os.ReadFile("test_data_dir/" + phoneNumber + "/" + toolName + ".json")
 		if readErr != nil {
-			log.Println("error reading test data file", readErr)
+			Logf("mcp_middleware_read_tool_data_failed", "session_id", sessionId, "tool", toolName, "err", readErr)
 			return mcp.NewToolResultError("error reading test data file"), nil
 		}
 		return mcp.NewToolResultText(string(data)), nil
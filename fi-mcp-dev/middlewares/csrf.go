@@ -0,0 +1,82 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/epifi/fi-mcp-lite/pkg"
+)
+
+const (
+	// csrfCookieNameSecure is used once the server is actually serving
+	// over TLS: __Host- cookies require Secure, no Domain, and Path=/,
+	// which only makes sense when the origin is HTTPS (or localhost).
+	csrfCookieNameSecure = "__Host-csrf"
+	// csrfCookieNameInsecure is used for the default TLS_MODE=off dev
+	// flow. Browsers silently drop Secure/__Host- cookies on any non-TLS
+	// origin other than localhost, which would otherwise make every
+	// /login POST fail CSRF validation out of the box.
+	csrfCookieNameInsecure = "csrf"
+	csrfFormField          = "csrf_token"
+	csrfTokenBytes         = 32
+)
+
+// NewCSRFToken mints a random token and sets it as a cookie on w, returning
+// the token so it can also be embedded as a hidden form field in the page
+// being rendered. The cookie is Secure and uses the __Host- prefix when
+// TLS is enabled, and falls back to a plain cookie when it isn't, so the
+// default non-TLS dev flow keeps working off localhost too.
+func NewCSRFToken(w http.ResponseWriter) (string, error) {
+	raw := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	secure := pkg.GetTLSMode() != "off"
+	name := csrfCookieNameInsecure
+	if secure {
+		name = csrfCookieNameSecure
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    token,
+		Path:     "/",
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return token, nil
+}
+
+// ValidCSRFToken reports whether r carries a csrf_token form field that
+// constant-time-matches the cookie set by NewCSRFToken.
+func ValidCSRFToken(r *http.Request) bool {
+	name := csrfCookieNameInsecure
+	if pkg.GetTLSMode() != "off" {
+		name = csrfCookieNameSecure
+	}
+	cookie, err := r.Cookie(name)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	submitted := r.FormValue(csrfFormField)
+	if submitted == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(submitted)) == 1
+}
+
+// AllowedMethod rejects any request whose method isn't method with a 405
+// and an Allow header, and otherwise delegates to h.
+func AllowedMethod(method string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
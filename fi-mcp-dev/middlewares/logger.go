@@ -0,0 +1,69 @@
+package middlewares
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Logger records one structured (logfmt-style) line per request, replacing
+// the ad-hoc log.Printf calls scattered through the handlers.
+func Logger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		Logf("request_complete",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"bytes", rec.bytes,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"session_id", r.Header.Get("X-Session-ID"),
+			"request_id", RequestIDFromContext(r.Context()),
+		)
+	})
+}
+
+// statusRecorder wraps a ResponseWriter to capture the status code and byte
+// count Logger needs, since http.ResponseWriter doesn't expose either.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Flush passes through to the wrapped ResponseWriter so streaming handlers
+// behind Logger can still flush.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Logf emits a single logfmt-style line: event plus alternating key/value
+// pairs. It's the shared structured-logging entry point for both this
+// package and main, replacing one-off log.Printf calls.
+func Logf(event string, kv ...any) {
+	var b strings.Builder
+	b.WriteString("event=")
+	b.WriteString(event)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fmt.Fprintf(&b, " %v=%q", kv[i], fmt.Sprint(kv[i+1]))
+	}
+	log.Println(b.String())
+}
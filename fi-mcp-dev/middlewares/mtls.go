@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/epifi/fi-mcp-lite/pkg"
+)
+
+// phoneNumberContextKey matches the "phone_number" key AuthMiddleware
+// already stashes in context for MCP tool calls, so both auth paths are
+// interchangeable to anything reading it downstream.
+const phoneNumberContextKey = "phone_number"
+
+// MTLSAuth authenticates requests from the client certificate's Common
+// Name when the server is running in mtls mode, letting MCP clients skip
+// the /login flow entirely. Requests without a client cert - or when the
+// server isn't in mtls mode - fall through unchanged to session-based auth.
+func MTLSAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if pkg.GetTLSMode() == "mtls" && r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			phoneNumber := r.TLS.PeerCertificates[0].Subject.CommonName
+			ctx := context.WithValue(r.Context(), phoneNumberContextKey, phoneNumber)
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ResolvePhoneNumber returns the authenticated phone number for r: the
+// identity bound by MTLSAuth if present, otherwise whatever phone number
+// the request's X-Session-ID session maps to.
+func ResolvePhoneNumber(r *http.Request) (string, bool) {
+	if phoneNumber, ok := r.Context().Value(phoneNumberContextKey).(string); ok && phoneNumber != "" {
+		return phoneNumber, true
+	}
+	return GetSession(r.Header.Get("X-Session-ID"))
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recovery catches panics from downstream handlers so one bad request can't
+// take the whole server down. It logs the panic and stack trace for
+// operators but returns an opaque JSON error to the client.
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				requestID := RequestIDFromContext(r.Context())
+				log.Printf("PANIC RECOVERED: request_id=%s err=%v\n%s", requestID, recovered, debug.Stack())
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(struct {
+					Error     string `json:"error"`
+					RequestID string `json:"request_id"`
+				}{Error: "internal", RequestID: requestID})
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
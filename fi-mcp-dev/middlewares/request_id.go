@@ -0,0 +1,49 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is both read from inbound requests and set on outbound
+// responses so request IDs survive a hop through a proxy or load balancer.
+const RequestIDHeader = "X-Request-ID"
+
+type contextKey string
+
+const requestIDContextKey contextKey = "request_id"
+
+// RequestID assigns a request ID to every request - reusing an inbound
+// X-Request-ID if the caller already set one - and stores it in both the
+// response header and the request context for downstream middleware and
+// handlers to read.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), requestIDContextKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext returns the request ID stashed by RequestID, or ""
+// if the context didn't come from a request that passed through it.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+func newRequestID() string {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		// crypto/rand failing is effectively unrecoverable, but a
+		// request ID collision is far less costly than crashing.
+		return "00000000000000000000000000000000"
+	}
+	return hex.EncodeToString(raw)
+}
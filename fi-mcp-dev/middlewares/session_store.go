@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"log"
+	"time"
+
+	"github.com/epifi/fi-mcp-lite/pkg"
+)
+
+// SessionStore abstracts the backing store for session-id -> phone-number
+// mappings so it can be swapped between in-memory, file-backed, and
+// Redis-backed implementations without touching the HTTP/MCP handlers.
+type SessionStore interface {
+	// Put stores phone against id, expiring it after ttl.
+	Put(id, phone string, ttl time.Duration)
+	// Get returns the phone number for id, and whether it was found and
+	// not yet expired.
+	Get(id string) (string, bool)
+	// Delete removes id from the store, if present.
+	Delete(id string)
+	// GC evicts all expired entries. Implementations that expire lazily
+	// on Get may treat this as a no-op.
+	GC()
+}
+
+// NewSessionStore builds the SessionStore selected by pkg config
+// (SESSION_STORE_BACKEND), defaulting to an in-memory store.
+func NewSessionStore() SessionStore {
+	switch backend := pkg.GetSessionStoreBackend(); backend {
+	case "file":
+		store, err := NewFileSessionStore(pkg.GetSessionFilePath())
+		if err != nil {
+			log.Fatalf("middlewares: could not open file session store: %v", err)
+		}
+		return store
+	case "redis":
+		return newRedisSessionStore(pkg.GetRedisURL())
+	case "memory", "":
+		return NewMemorySessionStore()
+	default:
+		log.Printf("middlewares: unknown SESSION_STORE_BACKEND %q, falling back to memory", backend)
+		return NewMemorySessionStore()
+	}
+}
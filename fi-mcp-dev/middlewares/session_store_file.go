@@ -0,0 +1,136 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileSessionStore persists sessions as JSON on disk so they survive
+// process restarts. It keeps an in-memory mirror for fast lookups and
+// rewrites the whole file on every mutation, which is fine at the scale
+// this mock server runs at. Like MemorySessionStore, it runs a background
+// janitor so expired entries are actually dropped from the file instead of
+// only being filtered out lazily on Get.
+type FileSessionStore struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]sessionEntry
+	stop    chan struct{}
+}
+
+// NewFileSessionStore loads path into memory, treating a missing file as an
+// empty store (the file is first written on the next mutation), and starts
+// its janitor goroutine.
+func NewFileSessionStore(path string) (*FileSessionStore, error) {
+	s := &FileSessionStore{
+		path:    path,
+		entries: make(map[string]sessionEntry),
+		stop:    make(chan struct{}),
+	}
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("loading session file %q: %w", path, err)
+	}
+	go s.janitor()
+	return s, nil
+}
+
+func (s *FileSessionStore) Put(id, phone string, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[id] = sessionEntry{phone: phone, expiresAt: time.Now().Add(ttl)}
+	s.persistLocked()
+}
+
+func (s *FileSessionStore) Get(id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[id]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", false
+	}
+	return entry.phone, true
+}
+
+func (s *FileSessionStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, id)
+	s.persistLocked()
+}
+
+func (s *FileSessionStore) GC() {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := false
+	for id, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, id)
+			changed = true
+		}
+	}
+	if changed {
+		s.persistLocked()
+	}
+}
+
+// Close stops the janitor goroutine. It is not part of the SessionStore
+// interface since only the backends that run one need it.
+func (s *FileSessionStore) Close() {
+	close(s.stop)
+}
+
+func (s *FileSessionStore) janitor() {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.GC()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+type fileSessionRecord struct {
+	Phone     string    `json:"phone"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (s *FileSessionStore) load() error {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	records := make(map[string]fileSessionRecord)
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+	for id, record := range records {
+		s.entries[id] = sessionEntry{phone: record.Phone, expiresAt: record.ExpiresAt}
+	}
+	return nil
+}
+
+// persistLocked must be called with s.mu held.
+func (s *FileSessionStore) persistLocked() {
+	records := make(map[string]fileSessionRecord, len(s.entries))
+	for id, entry := range s.entries {
+		records[id] = fileSessionRecord{Phone: entry.phone, ExpiresAt: entry.expiresAt}
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(s.path, data, 0o600)
+}
@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"sync"
+	"time"
+)
+
+const janitorInterval = time.Minute
+
+type sessionEntry struct {
+	phone     string
+	expiresAt time.Time
+}
+
+// MemorySessionStore keeps sessions in a map guarded by a mutex, with a
+// background janitor goroutine that periodically evicts expired entries.
+type MemorySessionStore struct {
+	mu      sync.RWMutex
+	entries map[string]sessionEntry
+	stop    chan struct{}
+}
+
+// NewMemorySessionStore starts a MemorySessionStore along with its janitor
+// goroutine.
+func NewMemorySessionStore() *MemorySessionStore {
+	s := &MemorySessionStore{
+		entries: make(map[string]sessionEntry),
+		stop:    make(chan struct{}),
+	}
+	go s.janitor()
+	return s
+}
+
+func (s *MemorySessionStore) Put(id, phone string, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[id] = sessionEntry{phone: phone, expiresAt: time.Now().Add(ttl)}
+}
+
+func (s *MemorySessionStore) Get(id string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[id]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", false
+	}
+	return entry.phone, true
+}
+
+func (s *MemorySessionStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, id)
+}
+
+func (s *MemorySessionStore) GC() {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, id)
+		}
+	}
+}
+
+// Close stops the janitor goroutine. It is not part of the SessionStore
+// interface since only the backends that run one need it.
+func (s *MemorySessionStore) Close() {
+	close(s.stop)
+}
+
+func (s *MemorySessionStore) janitor() {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.GC()
+		case <-s.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,50 @@
+//go:build redis
+
+package middlewares
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisSessionStore backs sessions with Redis SETEX/GET/DEL so sessions
+// can be shared across multiple server instances. It's only compiled in
+// with the "redis" build tag to keep the default build free of the
+// go-redis dependency.
+type RedisSessionStore struct {
+	client *redis.Client
+}
+
+func newRedisSessionStore(url string) SessionStore {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		log.Fatalf("middlewares: invalid SESSION_REDIS_URL %q: %v", url, err)
+	}
+	return &RedisSessionStore{client: redis.NewClient(opts)}
+}
+
+func (s *RedisSessionStore) Put(id, phone string, ttl time.Duration) {
+	if err := s.client.Set(context.Background(), id, phone, ttl).Err(); err != nil {
+		log.Printf("middlewares: redis session put failed: %v", err)
+	}
+}
+
+func (s *RedisSessionStore) Get(id string) (string, bool) {
+	phone, err := s.client.Get(context.Background(), id).Result()
+	if err != nil {
+		return "", false
+	}
+	return phone, true
+}
+
+func (s *RedisSessionStore) Delete(id string) {
+	if err := s.client.Del(context.Background(), id).Err(); err != nil {
+		log.Printf("middlewares: redis session delete failed: %v", err)
+	}
+}
+
+// GC is a no-op: Redis expires keys itself via the TTL passed to Put.
+func (s *RedisSessionStore) GC() {}
@@ -0,0 +1,13 @@
+//go:build !redis
+
+package middlewares
+
+import "log"
+
+// newRedisSessionStore is stubbed out unless the binary is built with the
+// "redis" tag, so SESSION_STORE_BACKEND=redis fails loudly instead of
+// silently falling back to memory.
+func newRedisSessionStore(string) SessionStore {
+	log.Fatalln("middlewares: SESSION_STORE_BACKEND=redis requires building with -tags redis")
+	return nil
+}
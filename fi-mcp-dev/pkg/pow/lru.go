@@ -0,0 +1,47 @@
+package pow
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruSet is a small fixed-capacity, concurrency-safe set used to remember
+// recently-redeemed challenge seeds so a solved challenge can't be
+// replayed within its validity window.
+type lruSet struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	index    map[string]*list.Element
+}
+
+func newLRUSet(capacity int) *lruSet {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &lruSet{
+		capacity: capacity,
+		order:    list.New(),
+		index:    make(map[string]*list.Element),
+	}
+}
+
+// addIfAbsent adds key to the set and returns true, or returns false if
+// key was already present.
+func (s *lruSet) addIfAbsent(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.index[key]; ok {
+		return false
+	}
+	s.index[key] = s.order.PushFront(key)
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		if oldest != nil {
+			s.order.Remove(oldest)
+			delete(s.index, oldest.Value.(string))
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPowDifficulty      = 20
+	defaultPowChallengeTTL    = 60 * time.Second
+	defaultPowReplayCacheSize = 10_000
+	defaultPowSecretIfUnset   = "fi-mcp-dev-insecure-pow-secret"
+)
+
+// GetPowSecret returns the HMAC secret used to sign PoW challenges,
+// configurable via POW_SECRET. Falls back to a fixed development secret
+// so the mock server still runs out of the box.
+func GetPowSecret() []byte {
+	secret := os.Getenv("POW_SECRET")
+	if secret == "" {
+		return []byte(defaultPowSecretIfUnset)
+	}
+	return []byte(secret)
+}
+
+// GetPowDifficulty returns the number of leading zero bits a PoW solution
+// must satisfy, configurable via POW_DIFFICULTY.
+func GetPowDifficulty() int {
+	raw := os.Getenv("POW_DIFFICULTY")
+	if raw == "" {
+		return defaultPowDifficulty
+	}
+	difficulty, err := strconv.Atoi(raw)
+	if err != nil || difficulty <= 0 {
+		return defaultPowDifficulty
+	}
+	return difficulty
+}
+
+// GetPowChallengeTTL returns how long a PoW challenge stays valid,
+// configurable via POW_CHALLENGE_TTL_SECONDS.
+func GetPowChallengeTTL() time.Duration {
+	raw := os.Getenv("POW_CHALLENGE_TTL_SECONDS")
+	if raw == "" {
+		return defaultPowChallengeTTL
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds <= 0 {
+		return defaultPowChallengeTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetPowReplayCacheSize returns how many recently-redeemed PoW seeds to
+// remember for replay protection, configurable via POW_REPLAY_CACHE_SIZE.
+func GetPowReplayCacheSize() int {
+	raw := os.Getenv("POW_REPLAY_CACHE_SIZE")
+	if raw == "" {
+		return defaultPowReplayCacheSize
+	}
+	size, err := strconv.Atoi(raw)
+	if err != nil || size <= 0 {
+		return defaultPowReplayCacheSize
+	}
+	return size
+}
@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultSessionTTL      = 30 * time.Minute
+	defaultSessionFilePath = "sessions.json"
+)
+
+// GetSessionStoreBackend selects the SessionStore implementation to use.
+// One of "memory" (default), "file", or "redis".
+func GetSessionStoreBackend() string {
+	backend := os.Getenv("SESSION_STORE_BACKEND")
+	if backend == "" {
+		return "memory"
+	}
+	return backend
+}
+
+// GetSessionTTL returns how long a session is valid for before it is
+// evicted, configurable via SESSION_TTL_SECONDS.
+func GetSessionTTL() time.Duration {
+	raw := os.Getenv("SESSION_TTL_SECONDS")
+	if raw == "" {
+		return defaultSessionTTL
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds <= 0 {
+		return defaultSessionTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetSessionFilePath returns the path used by the file-backed session
+// store, configurable via SESSION_FILE_PATH.
+func GetSessionFilePath() string {
+	path := os.Getenv("SESSION_FILE_PATH")
+	if path == "" {
+		return defaultSessionFilePath
+	}
+	return path
+}
+
+// GetRedisURL returns the connection URL for the Redis-backed session
+// store, configurable via SESSION_REDIS_URL.
+func GetRedisURL() string {
+	return os.Getenv("SESSION_REDIS_URL")
+}
@@ -0,0 +1,32 @@
+package pkg
+
+import "os"
+
+// GetTLSMode selects how the HTTP server terminates connections: "off"
+// (plain HTTP, the default), "tls" (server-only TLS), or "mtls" (TLS with
+// mandatory client certificates), configurable via TLS_MODE.
+func GetTLSMode() string {
+	mode := os.Getenv("TLS_MODE")
+	if mode == "" {
+		return "off"
+	}
+	return mode
+}
+
+// GetTLSCertFile returns the server certificate path, configurable via
+// TLS_CERT_FILE.
+func GetTLSCertFile() string {
+	return os.Getenv("TLS_CERT_FILE")
+}
+
+// GetTLSKeyFile returns the server private key path, configurable via
+// TLS_KEY_FILE.
+func GetTLSKeyFile() string {
+	return os.Getenv("TLS_KEY_FILE")
+}
+
+// GetTLSClientCAFile returns the CA bundle used to verify client
+// certificates in mtls mode, configurable via TLS_CLIENT_CA_FILE.
+func GetTLSClientCAFile() string {
+	return os.Getenv("TLS_CLIENT_CA_FILE")
+}
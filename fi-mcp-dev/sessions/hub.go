@@ -0,0 +1,92 @@
+// Package sessions tracks the live MCP SSE streams so that a second
+// connection for the same session can cleanly take over from the first,
+// and so a client that drops can resume from where it left off.
+package sessions
+
+import (
+	"context"
+	"sync"
+)
+
+// Frame is one MCP JSON-RPC message pushed down an SSE stream.
+type Frame struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Result  any    `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type entry struct {
+	frames chan Frame
+	cancel context.CancelFunc
+}
+
+// Hub keeps one active stream per session ID, plus a History per session
+// that outlives any individual connection so a reconnect can resume via
+// Last-Event-ID.
+type Hub struct {
+	mu        sync.Mutex
+	streams   map[string]*entry
+	histories map[string]*History
+}
+
+// NewHub builds an empty Hub.
+func NewHub() *Hub {
+	return &Hub{
+		streams:   make(map[string]*entry),
+		histories: make(map[string]*History),
+	}
+}
+
+// Open registers a new stream for sessionID, cancelling whatever stream was
+// previously running for that session - so a second connection with the
+// same X-Session-ID supersedes the first rather than running alongside it.
+// The returned History is the same instance across reconnects, so a client
+// resuming with Last-Event-ID sees frames pushed before it reconnected.
+// finish must be called once the handler returns, however it returns.
+func (h *Hub) Open(sessionID string) (ctx context.Context, frames chan Frame, history *History, finish func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if previous, ok := h.streams[sessionID]; ok {
+		previous.cancel()
+	}
+	history, ok := h.histories[sessionID]
+	if !ok {
+		history = newHistory(historySize)
+		h.histories[sessionID] = history
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &entry{
+		frames: make(chan Frame, 16),
+		cancel: cancel,
+	}
+	h.streams[sessionID] = e
+
+	finish = func() {
+		cancel()
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.streams[sessionID] == e {
+			delete(h.streams, sessionID)
+		}
+	}
+	return ctx, e.frames, history, finish
+}
+
+// Cancel stops the stream running for sessionID, if any, and reports
+// whether one was found. It also drops the session's History, since a
+// caller that explicitly cancels has no further use for resume.
+func (h *Hub) Cancel(sessionID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e, ok := h.streams[sessionID]
+	if !ok {
+		return false
+	}
+	e.cancel()
+	delete(h.streams, sessionID)
+	delete(h.histories, sessionID)
+	return true
+}
@@ -0,0 +1,100 @@
+package sessions
+
+import "sync"
+
+// historySize bounds how many frames a session keeps around for resume via
+// Last-Event-ID; older frames are simply not resumable. At the default
+// 32 KiB streamChunkBytes, 256 frames bound a fully-resumable response to
+// ~8 MiB - a reconnect for a larger response will be missing its earliest
+// chunks from Since, even though production itself isn't bounded by this
+// limit (see History.Offset).
+const historySize = 256
+
+// History remembers the last historySize frames sent on a stream, each
+// tagged with a monotonically increasing ID, so a reconnecting client can
+// ask to replay everything after the last one it saw (via Last-Event-ID).
+// It also tracks how much of the current production has completed, so a
+// reconnecting producer can continue from where it left off instead of
+// starting over.
+type History struct {
+	mu       sync.Mutex
+	capacity int
+	frames   []Frame
+	nextID   int64
+	offset   int64
+	done     bool
+}
+
+func newHistory(capacity int) *History {
+	return &History{capacity: capacity, frames: make([]Frame, 0, capacity)}
+}
+
+// Push assigns the next frame ID, appends the frame to the history, and
+// returns the stamped frame ready to send.
+func (h *History) Push(result any, errMsg string) Frame {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	frame := Frame{JSONRPC: "2.0", ID: h.nextID, Result: result, Error: errMsg}
+	h.frames = append(h.frames, frame)
+	if len(h.frames) > h.capacity {
+		h.frames = h.frames[len(h.frames)-h.capacity:]
+	}
+	return frame
+}
+
+// Since returns every frame with an ID greater than lastID, oldest first.
+func (h *History) Since(lastID int64) []Frame {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var out []Frame
+	for _, f := range h.frames {
+		if f.ID > lastID {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Reset clears offset and done, starting a fresh production from byte 0.
+// It does not clear already-pushed frames, since those may still be
+// replayed via Since by a client that hasn't reconnected yet.
+func (h *History) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.offset = 0
+	h.done = false
+}
+
+// Advance records that n more bytes of the current production have been
+// turned into frames, so a resuming producer knows where to continue from.
+func (h *History) Advance(n int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.offset += int64(n)
+}
+
+// Offset returns how many bytes of the current production have already
+// been turned into frames.
+func (h *History) Offset() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.offset
+}
+
+// MarkDone records that the current production has finished, so a
+// reconnect that has already seen every frame doesn't restart it.
+func (h *History) MarkDone() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.done = true
+}
+
+// Done reports whether the current production has finished.
+func (h *History) Done() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.done
+}
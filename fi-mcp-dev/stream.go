@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/epifi/fi-mcp-lite/middlewares"
+	"github.com/epifi/fi-mcp-lite/pkg"
+	"github.com/epifi/fi-mcp-lite/sessions"
+)
+
+const (
+	keepAliveInterval = 15 * time.Second
+	streamChunkBytes  = 32 * 1024
+)
+
+// streamHub tracks the one active SSE stream per session, so a second
+// connection with the same X-Session-ID cancels the first.
+var streamHub = sessions.NewHub()
+
+// manualMcpStreamHandler replaces the library's broken /mcp/stream handling.
+// Clients that Accept text/event-stream get a real SSE stream of MCP
+// JSON-RPC frames; everything else gets the original buffered JSON body,
+// so existing non-streaming clients keep working unchanged.
+func manualMcpStreamHandler(w http.ResponseWriter, r *http.Request) {
+	sessionId := r.Header.Get("X-Session-ID")
+	middlewares.Logf("stream_checking_session", "session_id", sessionId)
+
+	// ResolvePhoneNumber prefers the identity bound by an mTLS client
+	// certificate, falling back to the X-Session-ID session.
+	phoneNumber, ok := middlewares.ResolvePhoneNumber(r)
+	if !ok {
+		middlewares.Logf("stream_session_not_found", "session_id", sessionId)
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+	middlewares.Logf("stream_session_found", "session_id", sessionId, "phone_number", phoneNumber)
+
+	if !lo.Contains(pkg.GetAllowedMobileNumbers(), phoneNumber) {
+		http.Error(w, "Phone number is not allowed", http.StatusForbidden)
+		return
+	}
+
+	var requestBody struct {
+		ToolName string `json:"tool_name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Could not decode request body", http.StatusBadRequest)
+		return
+	}
+
+	if !wantsEventStream(r) {
+		writeBufferedToolResult(w, phoneNumber, requestBody.ToolName)
+		return
+	}
+	streamToolResult(w, r, sessionId, phoneNumber, requestBody.ToolName)
+}
+
+// mcpCancelHandler closes a running SSE stream for the calling session, if
+// one exists.
+func mcpCancelHandler(w http.ResponseWriter, r *http.Request) {
+	sessionId := r.Header.Get("X-Session-ID")
+	if sessionId == "" {
+		http.Error(w, "X-Session-ID header is required", http.StatusBadRequest)
+		return
+	}
+	if !streamHub.Cancel(sessionId) {
+		http.Error(w, "no stream running for session", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func wantsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+func writeBufferedToolResult(w http.ResponseWriter, phoneNumber, toolName string) {
+	filePath := "test_data_dir/" + phoneNumber + "/" + toolName + ".json"
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		middlewares.Logf("stream_read_tool_data_failed", "path", filePath, "err", err)
+		http.Error(w, "Could not read tool data", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// streamToolResult upgrades to SSE and pumps the tool's response down as
+// one or more MCP JSON-RPC frames, chunking large files instead of
+// buffering them whole. A reconnect bearing Last-Event-ID replays whatever
+// frames it missed and, if production hadn't finished yet, continues it
+// from where it left off rather than starting over.
+func streamToolResult(w http.ResponseWriter, r *http.Request, sessionId, phoneNumber, toolName string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, frames, history, finish := streamHub.Open(sessionId)
+	defer finish()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	resuming := false
+	if lastEventID, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64); err == nil {
+		for _, frame := range history.Since(lastEventID) {
+			writeSSEFrame(w, frame)
+		}
+		flusher.Flush()
+		if history.Done() {
+			// Everything the client is missing was just replayed above;
+			// starting a fresh producer here would re-send the whole file.
+			return
+		}
+		resuming = true
+	}
+
+	go produceToolFrames(ctx, frames, history, phoneNumber, toolName, resuming)
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ctx.Done():
+			return
+		case frame, open := <-frames:
+			if !open {
+				return
+			}
+			writeSSEFrame(w, frame)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// toolChunk is one piece of a tool's (possibly large) response. Data holds
+// this chunk's raw bytes base64-encoded, since sessions.Frame is marshaled
+// as JSON and arbitrary file bytes aren't valid JSON on their own - the
+// client must base64-decode each chunk individually and append the
+// resulting bytes in ID order; the base64 strings themselves must not be
+// concatenated before decoding. Final marks the last chunk of the response.
+type toolChunk struct {
+	Data  string `json:"data"`
+	Final bool   `json:"final"`
+}
+
+// produceToolFrames reads the tool's backing data file, splits it into
+// streamChunkBytes pieces, and pushes each as its own frame so large
+// responses don't have to be buffered in full before the first byte goes
+// out. It bails as soon as ctx is cancelled, e.g. by a superseding
+// connection or POST /mcp/cancel.
+//
+// resuming distinguishes a reconnect from a fresh request: on a fresh
+// request (resuming == false) history.Reset is called so production
+// starts from byte 0, discarding any offset left over from a prior tool
+// call on this session. On a reconnect (resuming == true) production
+// continues from history.Offset instead of re-reading and re-pushing
+// bytes the client has already been sent.
+func produceToolFrames(ctx context.Context, frames chan<- sessions.Frame, history *sessions.History, phoneNumber, toolName string, resuming bool) {
+	if !resuming {
+		history.Reset()
+	}
+
+	filePath := "test_data_dir/" + phoneNumber + "/" + toolName + ".json"
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		middlewares.Logf("stream_read_tool_data_failed", "path", filePath, "err", err)
+		select {
+		case frames <- history.Push(nil, "could not read tool data"):
+			history.MarkDone()
+			close(frames)
+		case <-ctx.Done():
+		}
+		return
+	}
+
+	if len(data) == 0 {
+		select {
+		case frames <- history.Push(toolChunk{Final: true}, ""):
+		case <-ctx.Done():
+			return
+		}
+		history.MarkDone()
+		close(frames)
+		return
+	}
+
+	for offset := int(history.Offset()); offset < len(data); offset += streamChunkBytes {
+		end := offset + streamChunkBytes
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := toolChunk{
+			Data:  base64.StdEncoding.EncodeToString(data[offset:end]),
+			Final: end == len(data),
+		}
+		// Push and Advance before the send, so a frame recorded in history
+		// is always counted in the offset even if ctx is cancelled first.
+		frame := history.Push(chunk, "")
+		history.Advance(end - offset)
+		select {
+		case frames <- frame:
+		case <-ctx.Done():
+			return
+		}
+	}
+	history.MarkDone()
+	close(frames)
+}
+
+func writeSSEFrame(w http.ResponseWriter, frame sessions.Frame) {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		middlewares.Logf("stream_marshal_frame_failed", "err", err)
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", frame.ID, data)
+}